@@ -8,7 +8,6 @@ import (
 	"encoding/json"
 	"fmt"
 	"io"
-	"net/http"
 	"os"
 	"os/exec"
 	"path/filepath"
@@ -29,6 +28,7 @@ type File struct {
 	URL          string `json:"url"`
 	UnpackedHash string `json:"unpackedhash"`
 	UnpackedSize int    `json:"unpackedsize"`
+	Rules        []Rule `json:"rules,omitempty"`
 }
 
 type AssetsInfo struct {
@@ -51,6 +51,9 @@ type App struct {
 	baseURL string
 	appName string
 
+	source          Source
+	manifestSources *sourceCache
+
 	clientInfo ClientInfo
 	assetsInfo AssetsInfo
 
@@ -69,9 +72,17 @@ type App struct {
 }
 
 func NewApp(logger *logrus.Logger, baseURL string, appName string, parallel int) *App {
+	source, err := newSource(baseURL)
+	if err != nil {
+		logger.Errorf("Error building source for %q, falling back to HTTP: %v", baseURL, err)
+		source = &httpSource{baseURL: baseURL}
+	}
+
 	return &App{
 		logger:          logger,
 		baseURL:         baseURL,
+		source:          source,
+		manifestSources: &sourceCache{},
 		queue:           make(chan File, 16),
 		cancel:          make(chan struct{}),
 		activeDownloads: make(map[string]struct{}),
@@ -82,6 +93,7 @@ func NewApp(logger *logrus.Logger, baseURL string, appName string, parallel int)
 
 func (a *App) startup(ctx context.Context) {
 	a.ctx = ctx
+	a.LoadCache()
 }
 
 func (a *App) OpenClientLocation() {
@@ -108,7 +120,7 @@ func (a *App) remoteAssetsJSON() string {
 }
 
 func (a *App) refreshManifests() {
-	err := a.downloadFile(a.baseURL+a.remoteClientJSON(), "client.json", false)
+	err := a.downloadFile(a.remoteClientJSON(), "client.json", false)
 	if err != nil {
 		a.logger.Errorf("Error downloading %s: %v", a.remoteClientJSON(), err)
 	}
@@ -122,7 +134,7 @@ func (a *App) refreshManifests() {
 		a.logger.Infof("Successfully loaded client.json: version=%s, files=%d", a.clientInfo.Version, len(a.clientInfo.Files))
 	}
 
-	err = a.downloadFile(a.baseURL+a.remoteAssetsJSON(), "assets.json", false)
+	err = a.downloadFile(a.remoteAssetsJSON(), "assets.json", false)
 	if err != nil {
 		a.logger.Errorf("Error downloading %s: %v", a.remoteAssetsJSON(), err)
 	}
@@ -148,28 +160,30 @@ func (a *App) Revision() int {
 }
 
 func (a *App) DownloadPercent() float64 {
-	if a.totalBytes == 0 {
+	total := atomic.LoadInt64(&a.totalBytes)
+	if total == 0 {
 		return 0
 	}
-	percent := float64(a.downloadedBytes) / float64(a.totalBytes) * 100
-	a.logger.Infof("Downloaded %d/%d files |  %d/%d bytes (%.2f%%)", a.downloadedFiles, a.totalFiles, a.downloadedBytes, a.totalBytes, percent)
+	downloaded := atomic.LoadInt64(&a.downloadedBytes)
+	percent := float64(downloaded) / float64(total) * 100
+	a.logger.Infof("Downloaded %d/%d files |  %d/%d bytes (%.2f%%)", atomic.LoadInt64(&a.downloadedFiles), atomic.LoadInt64(&a.totalFiles), downloaded, total, percent)
 	return percent
 }
 
 func (a *App) TotalFiles() int64 {
-	return a.totalFiles
+	return atomic.LoadInt64(&a.totalFiles)
 }
 
 func (a *App) TotalBytes() int64 {
-	return a.totalBytes
+	return atomic.LoadInt64(&a.totalBytes)
 }
 
 func (a *App) DownloadedFiles() int64 {
-	return a.downloadedFiles
+	return atomic.LoadInt64(&a.downloadedFiles)
 }
 
 func (a *App) DownloadedBytes() int64 {
-	return a.downloadedBytes
+	return atomic.LoadInt64(&a.downloadedBytes)
 }
 
 func (a *App) ToggleLocal(value bool) {
@@ -219,14 +233,245 @@ func (a *App) ActiveDownload() string {
 	return ""
 }
 
+// Update refreshes manifests and brings the local install up to date.
+// When a per-file manifest is present it drives a parallel delta update;
+// otherwise it falls back to the full client ZIP (first install).
 func (a *App) Update() {
+	a.refreshManifests()
+
+	if len(a.clientInfo.Files) == 0 && len(a.assetsInfo.Files) == 0 {
+		a.logger.Infof("No per-file manifest available, falling back to full client ZIP")
+		a.updateFromZip()
+		return
+	}
+
+	files, err := a.filesToUpdate()
+	if err != nil {
+		a.logger.Errorf("Error computing files to update: %v", err)
+		return
+	}
+
+	a.updateFromManifest(files)
+}
+
+// workerCount returns how many parallel download workers to run. The
+// value passed to NewApp takes priority; otherwise it falls back to the
+// "concurrentDownloads" viper setting, defaulting to 5.
+func (a *App) workerCount() int {
+	if a.parallel > 0 {
+		return a.parallel
+	}
+	if viper.IsSet("concurrentDownloads") {
+		if n := viper.GetInt("concurrentDownloads"); n > 0 {
+			return n
+		}
+	}
+	return 5
+}
+
+// CancelUpdate stops any in-flight updateFromManifest workers.
+func (a *App) CancelUpdate() {
+	a.mutex.Lock()
+	defer a.mutex.Unlock()
+	select {
+	case <-a.cancel:
+		// already cancelled
+	default:
+		close(a.cancel)
+	}
+}
+
+// updateFromManifest downloads the given files across a.workerCount()
+// parallel workers, tracking per-file progress via a.activeDownloads and
+// totals via atomics so DownloadPercent()/ActiveDownload() stay meaningful.
+func (a *App) updateFromManifest(files []File) {
+	a.logger.Infof("Updating %d files from manifest", len(files))
+
+	var totalBytes int64
+	for _, f := range files {
+		totalBytes += int64(f.PackedSize)
+	}
+	atomic.StoreInt64(&a.totalFiles, int64(len(files)))
+	atomic.StoreInt64(&a.downloadedFiles, 0)
+	atomic.StoreInt64(&a.totalBytes, totalBytes)
+	atomic.StoreInt64(&a.downloadedBytes, 0)
+
+	a.mutex.Lock()
+	cancel := make(chan struct{})
+	queue := make(chan File, len(files))
+	a.cancel = cancel
+	a.queue = queue
+	a.mutex.Unlock()
+
+	for _, f := range files {
+		queue <- f
+	}
+	close(queue)
+
+	workers := a.workerCount()
+	wg := sync.WaitGroup{}
+	wg.Add(workers)
+	for i := 0; i < workers; i++ {
+		go func() {
+			defer wg.Done()
+			a.updateWorker(cancel, queue)
+		}()
+	}
+	wg.Wait()
+
+	a.logger.Infof("Manifest update finished: %d/%d files", atomic.LoadInt64(&a.downloadedFiles), atomic.LoadInt64(&a.totalFiles))
+}
+
+func (a *App) updateWorker(cancel <-chan struct{}, queue <-chan File) {
+	for {
+		select {
+		case <-cancel:
+			return
+		case file, ok := <-queue:
+			if !ok {
+				return
+			}
+			if err := a.updateFile(file); err != nil {
+				a.logger.Errorf("Error updating %s: %v", file.LocalFile, err)
+			}
+		}
+	}
+}
+
+// updateFile downloads, verifies and installs a single manifest File,
+// tracking it in a.activeDownloads while in flight.
+func (a *App) updateFile(file File) error {
+	a.mutex.Lock()
+	a.activeDownloads[file.URL] = struct{}{}
+	a.mutex.Unlock()
+	defer func() {
+		a.mutex.Lock()
+		delete(a.activeDownloads, file.URL)
+		a.mutex.Unlock()
+	}()
+
+	return a.downloadManifestFile(file)
+}
+
+// downloadManifestFile downloads a manifest File, verifies PackedHash/
+// PackedSize on the compressed bytes, decompresses (LZMA when the source
+// URL is .lzma) into LocalFile and verifies UnpackedHash, removing
+// LocalFile again on a mismatch.
+// Packed artifacts are served from and saved to the content-addressable
+// cache (see cache.go) keyed by PackedHash, so re-downloads across
+// manifest revisions can be skipped when the same blob is referenced again.
+func (a *App) downloadManifestFile(file File) error {
+	if file.PackedHash != "" {
+		if cached, ok := cacheLookup(file.PackedHash); ok {
+			if hash, err := sha256Sum(cached.path); err == nil && hash == file.PackedHash {
+				a.logger.Infof("Using cached copy of %s (%s)", file.LocalFile, file.PackedHash)
+				atomic.AddInt64(&a.downloadedBytes, cached.size)
+				return a.installFromPacked(cached.path, file)
+			}
+			a.logger.Warnf("Cache entry %s failed verification, re-downloading", file.PackedHash)
+		}
+	}
+
+	tempFile := filepath.Join(os.TempDir(), fmt.Sprintf("%s_%s.part", a.appName, filepath.Base(file.LocalFile)))
+	defer os.Remove(tempFile)
+
+	src, path, err := a.manifestSources.get(file.URL)
+	if err != nil {
+		return err
+	}
+
+	// progress=false: a.totalBytes/a.downloadedBytes are pre-summed across
+	// every file in updateFromManifest and shared by every concurrent
+	// worker, so downloadResumable must not touch them here (see its doc
+	// comment). This file's bytes are added to the shared total once,
+	// below, after it has fully and successfully downloaded.
+	if err := a.downloadResumable(fmt.Sprintf("download %s", file.LocalFile), src, path, tempFile, false); err != nil {
+		return err
+	}
+
+	info, err := os.Stat(tempFile)
+	if err != nil {
+		return err
+	}
+	if file.PackedSize > 0 && info.Size() != int64(file.PackedSize) {
+		return fmt.Errorf("packed size mismatch for %s: expected %d, got %d", file.LocalFile, file.PackedSize, info.Size())
+	}
+	atomic.AddInt64(&a.downloadedBytes, info.Size())
+
+	if file.PackedHash != "" {
+		packedHash, err := sha256Sum(tempFile)
+		if err != nil {
+			return err
+		}
+		if packedHash != file.PackedHash {
+			return fmt.Errorf("packed hash mismatch for %s", file.LocalFile)
+		}
+
+		cachedPath, err := a.storeInCache(file.PackedHash, tempFile)
+		if err != nil {
+			a.logger.Errorf("Error caching %s: %v", file.LocalFile, err)
+			return a.installFromPacked(tempFile, file)
+		}
+		return a.installFromPacked(cachedPath, file)
+	}
+
+	return a.installFromPacked(tempFile, file)
+}
+
+// installFromPacked decompresses the packed artifact at srcPath (LZMA
+// when the source URL is .lzma) into file.LocalFile, verifying UnpackedHash.
+func (a *App) installFromPacked(srcPath string, file File) error {
+	localPath := filepath.Join(a.appDirectory(), file.LocalFile)
+	if err := os.MkdirAll(filepath.Dir(localPath), 0755); err != nil {
+		return err
+	}
+
+	in, err := os.Open(srcPath)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+
+	var unpackedReader io.Reader = in
+	if filepath.Ext(file.URL) == ".lzma" {
+		lzmaReader, err := lzma.NewReader(in)
+		if err != nil {
+			return err
+		}
+		unpackedReader = lzmaReader
+	}
+
+	dstFile, err := os.Create(localPath)
+	if err != nil {
+		return err
+	}
+
+	unpackedHash := sha256.New()
+	_, err = io.Copy(io.MultiWriter(dstFile, unpackedHash), unpackedReader)
+	dstFile.Close()
+	if err != nil {
+		return err
+	}
+
+	if file.UnpackedHash != "" && hex.EncodeToString(unpackedHash.Sum(nil)) != file.UnpackedHash {
+		os.Remove(localPath)
+		return fmt.Errorf("unpacked hash mismatch for %s", file.LocalFile)
+	}
+
+	atomic.AddInt64(&a.downloadedFiles, 1)
+	return nil
+}
+
+// updateFromZip performs the legacy first-install path: it downloads the
+// full client ZIP from GitHub Releases and extracts it wholesale.
+func (a *App) updateFromZip() {
 	// Download full client ZIP from GitHub Releases
 	a.logger.Infof("Downloading full client ZIP...")
-	a.totalFiles = 1
-	a.totalBytes = 0
-	a.downloadedFiles = 0
-	a.downloadedBytes = 0
-	
+	atomic.StoreInt64(&a.totalFiles, 1)
+	atomic.StoreInt64(&a.totalBytes, 0)
+	atomic.StoreInt64(&a.downloadedFiles, 0)
+	atomic.StoreInt64(&a.downloadedBytes, 0)
+
 	// Clean old client folders
 	a.logger.Infof("Cleaning old client folders...")
 	clientFolders := []string{"OTCLIENT", "OTCLIENT NORDEMON", "OTCLIENTE NORDEMON CRIPT", "client"}
@@ -239,42 +484,32 @@ func (a *App) Update() {
 	}
 	
 	// Get download URL from GitHub config
-	downloadURLPath := a.baseURL + "client_download_url.txt"
-	a.logger.Infof("Fetching download URL from: %s", downloadURLPath)
-	resp, err := http.Get(downloadURLPath)
+	a.logger.Infof("Fetching download URL from: %sclient_download_url.txt", a.baseURL)
+	urlBytes, err := a.source.Read("client_download_url.txt")
 	if err != nil {
 		a.logger.Errorf("Error fetching download URL: %v", err)
 		return
 	}
-	defer resp.Body.Close()
-	
-	urlBytes, err := io.ReadAll(resp.Body)
-	if err != nil {
-		a.logger.Errorf("Error reading download URL: %v", err)
-		return
-	}
 	zipURL := strings.TrimSpace(string(urlBytes))
 	a.logger.Infof("ZIP URL: %s", zipURL)
-	
-	err = a.downloadZip(zipURL, "client", true)
+
+	filename := filepath.Base(zipURL)
+	err = a.downloadZip(zipURL, "client", true, func(tempFile string) error {
+		return a.verifyClientZip(tempFile, filename)
+	})
 	if err != nil {
 		a.logger.Errorf("Error downloading client ZIP: %v", err)
 		return
 	}
-	
+
 	a.logger.Infof("Client ZIP downloaded and extracted successfully!")
-	
+
 	// Save version locally after successful download
-	versionURL := a.baseURL + "client_version.txt"
-	resp, err = http.Get(versionURL)
+	versionBytes, err := a.source.Read("client_version.txt")
 	if err == nil {
-		defer resp.Body.Close()
-		versionBytes, err := io.ReadAll(resp.Body)
-		if err == nil {
-			versionPath := filepath.Join(a.appDirectory(), "client_version.txt")
-			os.WriteFile(versionPath, versionBytes, 0644)
-			a.logger.Infof("Saved client version: %s", strings.TrimSpace(string(versionBytes)))
-		}
+		versionPath := filepath.Join(a.appDirectory(), "client_version.txt")
+		os.WriteFile(versionPath, versionBytes, 0644)
+		a.logger.Infof("Saved client version: %s", strings.TrimSpace(string(versionBytes)))
 	}
 }
 
@@ -293,12 +528,12 @@ var mapLocations = map[string]string{
 }
 
 func (a *App) DownloadMaps(kind int) {
-	a.totalBytes = 0
-	a.downloadedBytes = 0
-	a.totalFiles = 1
-	a.downloadedFiles = 0
+	atomic.StoreInt64(&a.totalBytes, 0)
+	atomic.StoreInt64(&a.downloadedBytes, 0)
+	atomic.StoreInt64(&a.totalFiles, 1)
+	atomic.StoreInt64(&a.downloadedFiles, 0)
 	a.logger.Infof("Downloading %s", mapKinds[kind])
-	err := a.downloadZip(mapKinds[kind], mapLocations[a.OS()], true)
+	err := a.downloadZip(mapKinds[kind], mapLocations[a.OS()], true, nil)
 	if err != nil {
 		a.logger.Errorf("Error downloading %s: %v", mapKinds[kind], err)
 		return
@@ -307,21 +542,13 @@ func (a *App) DownloadMaps(kind int) {
 
 func (a *App) NeedsUpdate() bool {
 	// Download remote version file
-	versionURL := a.baseURL + "client_version.txt"
-	resp, err := http.Get(versionURL)
+	remoteVersionBytes, err := a.source.Read("client_version.txt")
 	if err != nil {
 		a.logger.Errorf("Error downloading version file: %v", err)
 		// Fallback: check if init.lua exists in client folder
 		initPath := filepath.Join(a.appDirectory(), "client", "init.lua")
 		return !fileExists(initPath)
 	}
-	defer resp.Body.Close()
-	
-	remoteVersionBytes, err := io.ReadAll(resp.Body)
-	if err != nil {
-		a.logger.Errorf("Error reading remote version: %v", err)
-		return false
-	}
 	remoteVersion := strings.TrimSpace(string(remoteVersionBytes))
 	a.logger.Infof("Remote client version: %s", remoteVersion)
 	
@@ -358,9 +585,31 @@ func (a *App) appDirectory() string {
 	return filepath.Join(configDir, appName)
 }
 
+// filterByRules drops manifest Files whose Rules disallow them for the
+// current OS/arch or the currently enabled features, letting a single
+// manifest describe variant binaries and optional feature packs instead
+// of publishing separate client.<os>.json files per variant.
+func (a *App) filterByRules(files []File) []File {
+	arch := runtime.GOARCH
+	features := map[string]bool{
+		"music": a.MusicEnabled(),
+		"local": a.LocalEnabled(),
+	}
+
+	filtered := files[:0:0]
+	for _, file := range files {
+		if file.allowed(a.OS(), arch, features) {
+			filtered = append(filtered, file)
+		} else {
+			a.logger.Infof("Skipping %s: disallowed by rules for os=%s arch=%s", file.LocalFile, a.OS(), arch)
+		}
+	}
+	return filtered
+}
+
 func (a *App) filesToUpdate() ([]File, error) {
 	var files []File
-	filesTocheck := append(a.assetsInfo.Files, a.clientInfo.Files...)
+	filesTocheck := a.filterByRules(append(a.assetsInfo.Files, a.clientInfo.Files...))
 
 	mutex := sync.Mutex{}
 	wg := sync.WaitGroup{}
@@ -417,7 +666,12 @@ func readJSON(s string, d interface{}) error {
 	return nil
 }
 
-func (a *App) downloadZip(url, targetFolder string, progress bool) error {
+// downloadZip downloads zipURL (resuming a partial temp file and
+// retrying with backoff on failure) and extracts it into targetFolder.
+// When verify is non-nil it is called with the downloaded temp file path
+// before extraction; a non-nil error aborts the extraction and deletes
+// the temp file.
+func (a *App) downloadZip(zipURL, targetFolder string, progress bool, verify func(tempFile string) error) error {
 	// Create target folder path
 	targetPath := filepath.Join(a.appDirectory(), targetFolder)
 	err := os.MkdirAll(targetPath, 0755)
@@ -427,41 +681,34 @@ func (a *App) downloadZip(url, targetFolder string, progress bool) error {
 
 	// Download to temp file
 	tempFile := filepath.Join(os.TempDir(), "nordemon_download.zip")
-	out, err := os.Create(tempFile)
-	if err != nil {
-		return err
-	}
-	defer out.Close()
 
-	resp, err := http.Get(url)
+	src, err := newSource(zipURL)
 	if err != nil {
 		return err
 	}
-	defer resp.Body.Close()
 
-	if resp.StatusCode != http.StatusOK {
-		return fmt.Errorf("failed to download: status %d", resp.StatusCode)
+	if err := a.downloadResumable(fmt.Sprintf("client ZIP %s", zipURL), src, "", tempFile, progress); err != nil {
+		return err
 	}
 
-	a.totalBytes = resp.ContentLength
-
-	var reader io.Reader = resp.Body
-	if progress {
-		reader = io.TeeReader(reader, a)
-	}
-	_, err = io.Copy(out, reader)
-	if err != nil {
-		return err
+	if verify != nil {
+		if err := verify(tempFile); err != nil {
+			os.Remove(tempFile)
+			a.logger.Errorf("Verification failed for %s: %v", zipURL, err)
+			return err
+		}
 	}
-	out.Close()
 
 	// Extract directly to target folder, flattening structure if needed
 	err = unzipToFolder(tempFile, targetPath)
 	if err != nil {
 		return err
 	}
+	// A completed temp file must not be taken for a partial one by the
+	// next downloadZip call, which uses the same temp path.
+	os.Remove(tempFile)
 
-	a.downloadedFiles++
+	atomic.AddInt64(&a.downloadedFiles, 1)
 
 	return nil
 }
@@ -583,45 +830,47 @@ func unzipToFolder(src, dst string) error {
 	return nil
 }
 
-func (a *App) downloadFile(url, dst string, progress bool) error {
-	a.logger.Infof("Downloading %s to %s", url, dst)
+// downloadFile fetches path from a.source (relative to baseURL), resuming
+// a partial download and retrying with backoff on failure, and writes it
+// to dst under appDirectory().
+func (a *App) downloadFile(path, dst string, progress bool) error {
+	a.logger.Infof("Downloading %s to %s", path, dst)
 	dst = filepath.Join(a.appDirectory(), dst)
-	err := os.MkdirAll(filepath.Dir(dst), 0755)
-	if err != nil {
+	if err := os.MkdirAll(filepath.Dir(dst), 0755); err != nil {
 		return err
 	}
 
-	out, err := os.Create(dst)
-	if err != nil {
-		return err
-	}
-	defer out.Close()
-
-	resp, err := http.Get(url)
-	if err != nil {
+	tempFile := dst + ".part"
+	if err := a.downloadResumable(fmt.Sprintf("download %s", path), a.source, path, tempFile, progress); err != nil {
 		return err
 	}
-	defer resp.Body.Close()
 
-	if resp.StatusCode != http.StatusOK {
-		return fmt.Errorf("failed to download: status %d", resp.StatusCode)
-	}
+	if filepath.Ext(dst) != ".lzma" && filepath.Ext(path) == ".lzma" {
+		in, err := os.Open(tempFile)
+		if err != nil {
+			return err
+		}
 
-	var reader io.Reader = resp.Body
-	if progress {
-		reader = io.TeeReader(reader, a)
-	}
+		lzmaReader, err := lzma.NewReader(in)
+		if err != nil {
+			in.Close()
+			return err
+		}
 
-	if filepath.Ext(dst) != ".lzma" && filepath.Ext(url) == ".lzma" {
-		lzmaReader, err := lzma.NewReader(reader)
+		out, err := os.Create(dst)
 		if err != nil {
+			in.Close()
 			return err
 		}
-		reader = lzmaReader
-	}
 
-	_, err = io.Copy(out, reader)
-	if err != nil {
+		_, err = io.Copy(out, lzmaReader)
+		in.Close()
+		out.Close()
+		os.Remove(tempFile)
+		if err != nil {
+			return err
+		}
+	} else if err := os.Rename(tempFile, dst); err != nil {
 		return err
 	}
 
@@ -0,0 +1,163 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+)
+
+// cacheEntry describes one blob stored in the content-addressable cache.
+type cacheEntry struct {
+	size       int64
+	path       string
+	accessedAt time.Time
+}
+
+// cacheEntries holds the in-memory index built by LoadCache, keyed by
+// PackedHash. It is process-wide since the cache directory itself is.
+var cacheEntries sync.Map
+
+// cacheDirectory returns <appDirectory>/cache, where packed artifacts are
+// stored keyed by their PackedHash.
+func (a *App) cacheDirectory() string {
+	return filepath.Join(a.appDirectory(), "cache")
+}
+
+// LoadCache walks the cache directory and rebuilds the in-memory index.
+// Call it once at startup before any downloads are attempted.
+func (a *App) LoadCache() {
+	dir := a.cacheDirectory()
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		a.logger.Errorf("Error creating cache directory: %v", err)
+		return
+	}
+
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		a.logger.Errorf("Error reading cache directory: %v", err)
+		return
+	}
+
+	count := 0
+	for _, entry := range entries {
+		if entry.IsDir() {
+			continue
+		}
+		info, err := entry.Info()
+		if err != nil {
+			a.logger.Errorf("Error stating cache entry %s: %v", entry.Name(), err)
+			continue
+		}
+		cacheEntries.Store(entry.Name(), cacheEntry{
+			size:       info.Size(),
+			path:       filepath.Join(dir, entry.Name()),
+			accessedAt: info.ModTime(),
+		})
+		count++
+	}
+
+	a.logger.Infof("Loaded cache: %d entries", count)
+}
+
+// cacheLookup returns the cache entry for a PackedHash, if any, and marks
+// it as used now (in memory and in the file's mtime, which LoadCache reads).
+func cacheLookup(hash string) (cacheEntry, bool) {
+	v, ok := cacheEntries.Load(hash)
+	if !ok {
+		return cacheEntry{}, false
+	}
+	ce := v.(cacheEntry)
+	ce.accessedAt = time.Now()
+	os.Chtimes(ce.path, ce.accessedAt, ce.accessedAt)
+	cacheEntries.Store(hash, ce)
+	return ce, true
+}
+
+// storeInCache atomically moves tempPath into the cache directory under
+// hash and records it in the in-memory index, returning its final path.
+func (a *App) storeInCache(hash, tempPath string) (string, error) {
+	if hash == "" {
+		return "", fmt.Errorf("cannot cache a file with no PackedHash")
+	}
+
+	dir := a.cacheDirectory()
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return "", err
+	}
+
+	dst := filepath.Join(dir, hash)
+	if err := os.Rename(tempPath, dst); err != nil {
+		return "", err
+	}
+
+	info, err := os.Stat(dst)
+	if err != nil {
+		return "", err
+	}
+
+	cacheEntries.Store(hash, cacheEntry{size: info.Size(), path: dst, accessedAt: info.ModTime()})
+	return dst, nil
+}
+
+// PruneCache evicts least-recently-used cache entries until the cache's
+// total size is at or below maxBytes.
+func (a *App) PruneCache(maxBytes int64) {
+	type keyedEntry struct {
+		hash string
+		cacheEntry
+	}
+
+	var all []keyedEntry
+	var total int64
+	cacheEntries.Range(func(k, v interface{}) bool {
+		ce := v.(cacheEntry)
+		all = append(all, keyedEntry{hash: k.(string), cacheEntry: ce})
+		total += ce.size
+		return true
+	})
+
+	if total <= maxBytes {
+		return
+	}
+
+	sort.Slice(all, func(i, j int) bool {
+		return all[i].accessedAt.Before(all[j].accessedAt)
+	})
+
+	for _, e := range all {
+		if total <= maxBytes {
+			break
+		}
+		if err := os.Remove(e.path); err != nil {
+			a.logger.Errorf("Error pruning cache entry %s: %v", e.hash, err)
+			continue
+		}
+		cacheEntries.Delete(e.hash)
+		total -= e.size
+	}
+}
+
+// CacheSize returns the total size in bytes of all cached artifacts.
+// Exposed to the frontend alongside ToggleLocal/ToggleMusic.
+func (a *App) CacheSize() int64 {
+	var total int64
+	cacheEntries.Range(func(_, v interface{}) bool {
+		total += v.(cacheEntry).size
+		return true
+	})
+	return total
+}
+
+// ClearCache deletes the entire cache directory and resets the index.
+// Exposed to the frontend alongside ToggleLocal/ToggleMusic.
+func (a *App) ClearCache() {
+	if err := os.RemoveAll(a.cacheDirectory()); err != nil {
+		a.logger.Errorf("Error clearing cache: %v", err)
+		return
+	}
+	cacheEntries = sync.Map{}
+	a.logger.Infof("Cache cleared")
+}
@@ -0,0 +1,100 @@
+package main
+
+import (
+	"bufio"
+	"crypto/ed25519"
+	"encoding/hex"
+	"fmt"
+	"strings"
+)
+
+// clientSigningKey is the hex-encoded Ed25519 public key used to verify
+// client_checksum.txt.sig, baked into the launcher binary at build time
+// (e.g. via -ldflags "-X main.clientSigningKey=...") so the checksum
+// file itself is authenticated end-to-end. Left empty, signature
+// verification is skipped and only the sha256sum content is trusted.
+var clientSigningKey string
+
+// parseSha256Sums parses the standard `sha256sum` output format
+// (`<hex digest>  <filename>` per line) into a filename->digest map.
+func parseSha256Sums(data []byte) (map[string]string, error) {
+	sums := make(map[string]string)
+
+	scanner := bufio.NewScanner(strings.NewReader(string(data)))
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" {
+			continue
+		}
+		fields := strings.Fields(line)
+		if len(fields) < 2 {
+			return nil, fmt.Errorf("malformed checksum line: %q", line)
+		}
+		sums[fields[len(fields)-1]] = fields[0]
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	return sums, nil
+}
+
+// verifyChecksumSignature verifies checksumData against sigData using
+// clientSigningKey. With no key baked in, verification is a no-op.
+func verifyChecksumSignature(checksumData, sigData []byte) error {
+	if clientSigningKey == "" {
+		return nil
+	}
+
+	key, err := hex.DecodeString(clientSigningKey)
+	if err != nil || len(key) != ed25519.PublicKeySize {
+		return fmt.Errorf("invalid clientSigningKey baked into binary")
+	}
+
+	if !ed25519.Verify(ed25519.PublicKey(key), checksumData, sigData) {
+		return fmt.Errorf("client_checksum.txt signature verification failed")
+	}
+
+	return nil
+}
+
+// verifyClientZip fetches client_checksum.txt (and, when present,
+// client_checksum.txt.sig) from a.source and checks tempFile's sha256
+// against the entry for filename, refusing a mismatch rather than
+// trusting whatever bytes came back from the release URL.
+func (a *App) verifyClientZip(tempFile, filename string) error {
+	checksumData, err := a.source.Read("client_checksum.txt")
+	if err != nil {
+		return fmt.Errorf("error fetching client_checksum.txt: %w", err)
+	}
+
+	sigData, sigErr := a.source.Read("client_checksum.txt.sig")
+	if sigErr == nil {
+		if err := verifyChecksumSignature(checksumData, sigData); err != nil {
+			return err
+		}
+	} else if clientSigningKey != "" {
+		return fmt.Errorf("client_checksum.txt.sig required but missing: %w", sigErr)
+	}
+
+	sums, err := parseSha256Sums(checksumData)
+	if err != nil {
+		return fmt.Errorf("error parsing client_checksum.txt: %w", err)
+	}
+
+	expected, ok := sums[filename]
+	if !ok {
+		return fmt.Errorf("no checksum entry for %s in client_checksum.txt", filename)
+	}
+
+	actual, err := sha256Sum(tempFile)
+	if err != nil {
+		return err
+	}
+
+	if actual != expected {
+		return fmt.Errorf("checksum mismatch for %s: expected %s, got %s", filename, expected, actual)
+	}
+
+	return nil
+}
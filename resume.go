@@ -0,0 +1,176 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"math/rand"
+	"net/http"
+	"os"
+	"sync/atomic"
+	"time"
+)
+
+const (
+	maxDownloadAttempts = 5
+	retryBaseDelay      = 500 * time.Millisecond
+	retryMaxDelay       = 8 * time.Second
+)
+
+// errRangeNotSatisfiable is returned by RangeSource.OpenRange when the
+// source reports 416: the destination file already holds everything the
+// source has to offer.
+var errRangeNotSatisfiable = errors.New("requested range not satisfiable")
+
+// RangeSource is implemented by Sources that can resume a download from
+// a byte offset. Sources without it (file, ftp, sftp) are simply
+// restarted from scratch on retry.
+type RangeSource interface {
+	OpenRange(path string, offset int64) (io.ReadCloser, int64, error)
+}
+
+func (s *httpSource) OpenRange(path string, offset int64) (io.ReadCloser, int64, error) {
+	req, err := http.NewRequest(http.MethodGet, s.baseURL+path, nil)
+	if err != nil {
+		return nil, 0, err
+	}
+	if offset > 0 {
+		req.Header.Set("Range", fmt.Sprintf("bytes=%d-", offset))
+	}
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	switch resp.StatusCode {
+	case http.StatusPartialContent:
+		return resp.Body, resp.ContentLength, nil
+	case http.StatusOK:
+		// The server ignored Range and sent the whole file: skip the bytes
+		// the caller already holds so the returned reader starts at offset.
+		if offset > 0 {
+			if _, err := io.CopyN(io.Discard, resp.Body, offset); err != nil {
+				resp.Body.Close()
+				return nil, 0, err
+			}
+		}
+		size := resp.ContentLength
+		if size >= 0 {
+			size -= offset
+		}
+		return resp.Body, size, nil
+	case http.StatusRequestedRangeNotSatisfiable:
+		resp.Body.Close()
+		return nil, 0, errRangeNotSatisfiable
+	default:
+		resp.Body.Close()
+		return nil, 0, fmt.Errorf("failed to open %s: status %d", s.baseURL+path, resp.StatusCode)
+	}
+}
+
+// ctxDone returns a.ctx's done channel, or nil (which blocks forever in
+// a select) when no context has been wired up yet, e.g. in tests.
+func (a *App) ctxDone() <-chan struct{} {
+	if a.ctx == nil {
+		return nil
+	}
+	return a.ctx.Done()
+}
+
+// withRetry calls attempt up to maxDownloadAttempts times, backing off
+// exponentially with jitter between failures. a.ctx being cancelled is
+// treated as terminal rather than retried.
+func (a *App) withRetry(name string, attempt func() error) error {
+	var err error
+	for try := 0; try < maxDownloadAttempts; try++ {
+		if try > 0 {
+			delay := retryBaseDelay << uint(try-1)
+			if delay > retryMaxDelay {
+				delay = retryMaxDelay
+			}
+			delay = time.Duration(float64(delay) * (0.5 + rand.Float64()))
+			a.logger.Infof("Retrying %s in %s (attempt %d/%d)", name, delay, try+1, maxDownloadAttempts)
+			select {
+			case <-time.After(delay):
+			case <-a.ctxDone():
+				return context.Canceled
+			}
+		}
+
+		err = attempt()
+		if err == nil {
+			return nil
+		}
+		if errors.Is(err, context.Canceled) {
+			return err
+		}
+		a.logger.Errorf("%s failed (attempt %d/%d): %v", name, try+1, maxDownloadAttempts, err)
+	}
+	return fmt.Errorf("%s failed after %d attempts: %w", name, maxDownloadAttempts, err)
+}
+
+// downloadResumable downloads path from src into destFile, resuming from
+// destFile's existing size when src implements RangeSource, and retrying
+// with exponential backoff on failure. progress distinguishes two kinds
+// of caller: with progress=true (downloadZip, downloadFile) this call
+// owns a.totalBytes/a.downloadedBytes outright for its single file and
+// may set them directly. With progress=false (downloadManifestFile) the
+// fields are pre-summed across every file in the manifest and shared by
+// every concurrent worker, so this call must not touch them at all; the
+// caller is responsible for adding its own delta once the file is done.
+func (a *App) downloadResumable(name string, src Source, path, destFile string, progress bool) error {
+	return a.withRetry(name, func() error {
+		var offset int64
+		if info, err := os.Stat(destFile); err == nil {
+			offset = info.Size()
+		}
+
+		rangeSrc, canResume := src.(RangeSource)
+
+		var rc io.ReadCloser
+		var size int64
+		var err error
+		flag := os.O_CREATE | os.O_WRONLY | os.O_TRUNC
+
+		if offset > 0 && canResume {
+			rc, size, err = rangeSrc.OpenRange(path, offset)
+			if errors.Is(err, errRangeNotSatisfiable) {
+				// destFile already holds everything the source has to offer.
+				return nil
+			}
+			if err == nil {
+				flag = os.O_CREATE | os.O_WRONLY | os.O_APPEND
+			}
+		} else {
+			offset = 0
+			rc, size, err = src.Open(path)
+		}
+		if err != nil {
+			return err
+		}
+		defer rc.Close()
+
+		out, err := os.OpenFile(destFile, flag, 0644)
+		if err != nil {
+			return err
+		}
+		defer out.Close()
+
+		if progress {
+			if size >= 0 {
+				a.totalBytes = offset + size
+			}
+			atomic.StoreInt64(&a.downloadedBytes, offset)
+		}
+
+		var reader io.Reader = rc
+		if progress {
+			reader = io.TeeReader(reader, a)
+		}
+
+		_, err = io.Copy(out, reader)
+		return err
+	})
+}
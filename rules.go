@@ -0,0 +1,50 @@
+package main
+
+// RuleOS matches a Rule against the current platform. Empty fields act
+// as wildcards. Version is accepted for manifest-schema compatibility
+// with Minecraft-style rule sets but isn't evaluated: this launcher has
+// no existing way to probe the host OS version number.
+type RuleOS struct {
+	Name    string `json:"name,omitempty"`
+	Version string `json:"version,omitempty"`
+	Arch    string `json:"arch,omitempty"`
+}
+
+// Rule allows or disallows a manifest File depending on the current OS,
+// architecture, and enabled features (e.g. MusicEnabled()). A File's
+// Rules are evaluated top-to-bottom with last-match-wins semantics; the
+// default when no rule matches is allow.
+type Rule struct {
+	Action   string          `json:"action"`
+	OS       RuleOS          `json:"os,omitempty"`
+	Features map[string]bool `json:"features,omitempty"`
+}
+
+// Matches reports whether the rule applies to the given platform and
+// feature set. Empty/nil fields act as wildcards.
+func (r Rule) Matches(os, arch string, features map[string]bool) bool {
+	if r.OS.Name != "" && r.OS.Name != os {
+		return false
+	}
+	if r.OS.Arch != "" && r.OS.Arch != arch {
+		return false
+	}
+	for feature, want := range r.Features {
+		if features[feature] != want {
+			return false
+		}
+	}
+	return true
+}
+
+// allowed evaluates f.Rules top-to-bottom (last match wins), defaulting
+// to allow when no rule matches or the file carries no rules at all.
+func (f File) allowed(os, arch string, features map[string]bool) bool {
+	allow := true
+	for _, rule := range f.Rules {
+		if rule.Matches(os, arch, features) {
+			allow = rule.Action == "allow"
+		}
+	}
+	return allow
+}
@@ -0,0 +1,79 @@
+package main
+
+import "testing"
+
+func TestRuleMatchesWildcards(t *testing.T) {
+	r := Rule{Action: "allow"}
+	if !r.Matches("windows", "amd64", nil) {
+		t.Fatal("expected empty-field rule to match any platform")
+	}
+}
+
+func TestRuleMatchesOS(t *testing.T) {
+	r := Rule{Action: "allow", OS: RuleOS{Name: "windows"}}
+	if !r.Matches("windows", "amd64", nil) {
+		t.Fatal("expected rule to match windows")
+	}
+	if r.Matches("linux", "amd64", nil) {
+		t.Fatal("expected rule not to match linux")
+	}
+}
+
+func TestRuleMatchesArch(t *testing.T) {
+	r := Rule{Action: "allow", OS: RuleOS{Arch: "arm64"}}
+	if r.Matches("linux", "amd64", nil) {
+		t.Fatal("expected rule not to match amd64")
+	}
+	if !r.Matches("linux", "arm64", nil) {
+		t.Fatal("expected rule to match arm64")
+	}
+}
+
+func TestRuleMatchesFeatures(t *testing.T) {
+	r := Rule{Action: "allow", Features: map[string]bool{"music": true}}
+	if r.Matches("linux", "amd64", map[string]bool{"music": false}) {
+		t.Fatal("expected rule not to match when feature is disabled")
+	}
+	if !r.Matches("linux", "amd64", map[string]bool{"music": true}) {
+		t.Fatal("expected rule to match when feature is enabled")
+	}
+}
+
+func TestFileAllowedDefaultsToAllow(t *testing.T) {
+	f := File{}
+	if !f.allowed("windows", "amd64", nil) {
+		t.Fatal("expected a file with no rules to be allowed by default")
+	}
+}
+
+func TestFileAllowedLastMatchWins(t *testing.T) {
+	f := File{Rules: []Rule{
+		{Action: "allow"},
+		{Action: "disallow", OS: RuleOS{Name: "linux"}},
+		{Action: "allow", OS: RuleOS{Arch: "arm64"}},
+	}}
+
+	if f.allowed("linux", "amd64", nil) {
+		t.Fatal("expected linux/amd64 to be disallowed by the second rule")
+	}
+	if !f.allowed("linux", "arm64", nil) {
+		t.Fatal("expected linux/arm64 to be re-allowed by the later, more specific rule")
+	}
+	if !f.allowed("windows", "amd64", nil) {
+		t.Fatal("expected windows to stay allowed, since no disallow rule matches it")
+	}
+}
+
+func TestFileAllowedDisallowPrecedence(t *testing.T) {
+	f := File{Rules: []Rule{
+		{Action: "disallow"},
+		{Action: "allow", OS: RuleOS{Name: "windows"}},
+	}}
+
+	if !f.allowed("windows", "amd64", nil) {
+		t.Fatal("expected windows to be allowed by the later, more specific rule")
+	}
+	if f.allowed("linux", "amd64", nil) {
+		t.Fatal("expected linux to stay disallowed by the blanket first rule")
+	}
+}
@@ -0,0 +1,375 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sync"
+
+	"github.com/jlaffaye/ftp"
+	"github.com/pkg/sftp"
+	"golang.org/x/crypto/ssh"
+)
+
+// Source abstracts the transport used to fetch manifests and assets, so
+// Update/downloadFile/downloadZip don't care whether they're reading from
+// an HTTP server, a mounted LAN share, an FTP mirror, or an SFTP server.
+// A Source is rooted at some base location; paths passed to its methods
+// are relative to that root (an empty path addresses the root itself).
+type Source interface {
+	Exists(path string) (bool, error)
+	Open(path string) (io.ReadCloser, int64, error)
+	Read(path string) ([]byte, error)
+}
+
+// newSource builds a Source rooted at rawURL, picking an implementation
+// by URL scheme. Credentials, when needed, go in the URL userinfo.
+func newSource(rawURL string) (Source, error) {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return nil, fmt.Errorf("invalid source URL %q: %w", rawURL, err)
+	}
+
+	switch u.Scheme {
+	case "http", "https":
+		return &httpSource{baseURL: rawURL}, nil
+	case "file":
+		return &fileSource{root: u.Path}, nil
+	case "ftp":
+		password, _ := u.User.Password()
+		return &ftpSource{
+			addr:     withDefaultPort(u.Host, "21"),
+			username: u.User.Username(),
+			password: password,
+			root:     u.Path,
+		}, nil
+	case "sftp":
+		password, _ := u.User.Password()
+		return &sftpSource{
+			addr:     withDefaultPort(u.Host, "22"),
+			username: u.User.Username(),
+			password: password,
+			root:     u.Path,
+		}, nil
+	default:
+		return nil, fmt.Errorf("unsupported source scheme %q", u.Scheme)
+	}
+}
+
+// sourceCache lets unrelated downloads that share a scheme+host (e.g. the
+// per-file URLs in a manifest served off one FTP/SFTP mirror) reuse a
+// single Source, and with it a single underlying connection, instead of
+// every caller dialing and logging in fresh. Safe for concurrent use; the
+// returned Source itself is responsible for serializing its own commands
+// (see ftpSource).
+type sourceCache struct {
+	mutex   sync.Mutex
+	sources map[string]Source
+}
+
+// get returns a shared Source rooted at rawURL's scheme+host, along with
+// the path (rawURL's path and query) to pass to that Source's methods.
+// file:// URLs aren't pooled, since local/mounted access has no
+// connection to reuse.
+func (c *sourceCache) get(rawURL string) (Source, string, error) {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return nil, "", fmt.Errorf("invalid source URL %q: %w", rawURL, err)
+	}
+
+	path := u.Path
+	if u.RawQuery != "" {
+		path += "?" + u.RawQuery
+	}
+
+	if u.Scheme == "file" {
+		src, err := newSource(rawURL)
+		return src, "", err
+	}
+
+	root := u.Scheme + "://"
+	if u.User != nil {
+		root += u.User.String() + "@"
+	}
+	root += u.Host
+
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	if c.sources == nil {
+		c.sources = make(map[string]Source)
+	}
+	src, ok := c.sources[root]
+	if !ok {
+		src, err = newSource(root)
+		if err != nil {
+			return nil, "", err
+		}
+		c.sources[root] = src
+	}
+
+	return src, path, nil
+}
+
+func withDefaultPort(host, port string) string {
+	if _, _, err := net.SplitHostPort(host); err == nil {
+		return host
+	}
+	return host + ":" + port
+}
+
+// httpSource serves assets over plain HTTP(S).
+type httpSource struct {
+	baseURL string
+}
+
+func (s *httpSource) Exists(path string) (bool, error) {
+	resp, err := http.Head(s.baseURL + path)
+	if err != nil {
+		return false, err
+	}
+	defer resp.Body.Close()
+	return resp.StatusCode == http.StatusOK, nil
+}
+
+func (s *httpSource) Open(path string) (io.ReadCloser, int64, error) {
+	resp, err := http.Get(s.baseURL + path)
+	if err != nil {
+		return nil, 0, err
+	}
+	if resp.StatusCode != http.StatusOK {
+		resp.Body.Close()
+		return nil, 0, fmt.Errorf("failed to open %s: status %d", s.baseURL+path, resp.StatusCode)
+	}
+	return resp.Body, resp.ContentLength, nil
+}
+
+func (s *httpSource) Read(path string) ([]byte, error) {
+	rc, _, err := s.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer rc.Close()
+	return io.ReadAll(rc)
+}
+
+// fileSource serves assets from a local or mounted network share, for
+// server owners who'd rather not stand up an HTTP server.
+type fileSource struct {
+	root string
+}
+
+func (s *fileSource) Exists(path string) (bool, error) {
+	_, err := os.Stat(filepath.Join(s.root, path))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return false, nil
+		}
+		return false, err
+	}
+	return true, nil
+}
+
+func (s *fileSource) Open(path string) (io.ReadCloser, int64, error) {
+	f, err := os.Open(filepath.Join(s.root, path))
+	if err != nil {
+		return nil, 0, err
+	}
+	info, err := f.Stat()
+	if err != nil {
+		f.Close()
+		return nil, 0, err
+	}
+	return f, info.Size(), nil
+}
+
+func (s *fileSource) Read(path string) ([]byte, error) {
+	return os.ReadFile(filepath.Join(s.root, path))
+}
+
+// ftpSource serves assets from an FTP mirror. The underlying client is
+// not concurrency-safe, so every command-issuing method serializes on
+// mutex; Open holds the lock until the returned reader is closed, since
+// a second command must not be issued while a data transfer is in flight.
+type ftpSource struct {
+	addr     string
+	username string
+	password string
+	root     string
+
+	mutex sync.Mutex
+	conn  *ftp.ServerConn
+}
+
+// connect lazily dials and logs in. Callers must hold s.mutex.
+func (s *ftpSource) connect() (*ftp.ServerConn, error) {
+	if s.conn != nil {
+		return s.conn, nil
+	}
+
+	conn, err := ftp.Dial(s.addr)
+	if err != nil {
+		return nil, err
+	}
+	if s.username != "" {
+		if err := conn.Login(s.username, s.password); err != nil {
+			conn.Quit()
+			return nil, err
+		}
+	}
+	s.conn = conn
+	return conn, nil
+}
+
+func (s *ftpSource) Exists(path string) (bool, error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	conn, err := s.connect()
+	if err != nil {
+		return false, err
+	}
+	if _, err := conn.FileSize(filepath.Join(s.root, path)); err != nil {
+		return false, nil
+	}
+	return true, nil
+}
+
+func (s *ftpSource) Open(path string) (io.ReadCloser, int64, error) {
+	s.mutex.Lock()
+
+	conn, err := s.connect()
+	if err != nil {
+		s.mutex.Unlock()
+		return nil, 0, err
+	}
+
+	full := filepath.Join(s.root, path)
+	size, err := conn.FileSize(full)
+	if err != nil {
+		size = -1
+	}
+
+	resp, err := conn.Retr(full)
+	if err != nil {
+		s.mutex.Unlock()
+		return nil, 0, err
+	}
+
+	return &unlockingReadCloser{ReadCloser: resp, unlock: s.mutex.Unlock}, size, nil
+}
+
+func (s *ftpSource) Read(path string) ([]byte, error) {
+	rc, _, err := s.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer rc.Close()
+	return io.ReadAll(rc)
+}
+
+// unlockingReadCloser releases a held lock exactly once, when Close is
+// called, so a Source.Open caller can stream the body at its own pace
+// without another goroutine issuing a command on the same connection.
+type unlockingReadCloser struct {
+	io.ReadCloser
+	unlock func()
+	once   sync.Once
+}
+
+func (u *unlockingReadCloser) Close() error {
+	err := u.ReadCloser.Close()
+	u.once.Do(u.unlock)
+	return err
+}
+
+// sftpSource serves assets over SFTP. Unlike FTP, SFTP multiplexes
+// requests over a single SSH channel, so concurrent reads don't need to
+// be serialized beyond the short commands used to open them.
+type sftpSource struct {
+	addr     string
+	username string
+	password string
+	root     string
+
+	mutex  sync.Mutex
+	sshc   *ssh.Client
+	client *sftp.Client
+}
+
+// connect lazily dials and authenticates. Callers must hold s.mutex.
+func (s *sftpSource) connect() (*sftp.Client, error) {
+	if s.client != nil {
+		return s.client, nil
+	}
+
+	config := &ssh.ClientConfig{
+		User:            s.username,
+		Auth:            []ssh.AuthMethod{ssh.Password(s.password)},
+		HostKeyCallback: ssh.InsecureIgnoreHostKey(),
+	}
+
+	sshc, err := ssh.Dial("tcp", s.addr, config)
+	if err != nil {
+		return nil, err
+	}
+
+	client, err := sftp.NewClient(sshc)
+	if err != nil {
+		sshc.Close()
+		return nil, err
+	}
+
+	s.sshc = sshc
+	s.client = client
+	return client, nil
+}
+
+func (s *sftpSource) Exists(path string) (bool, error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	client, err := s.connect()
+	if err != nil {
+		return false, err
+	}
+	if _, err := client.Stat(filepath.Join(s.root, path)); err != nil {
+		return false, nil
+	}
+	return true, nil
+}
+
+func (s *sftpSource) Open(path string) (io.ReadCloser, int64, error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	client, err := s.connect()
+	if err != nil {
+		return nil, 0, err
+	}
+
+	full := filepath.Join(s.root, path)
+	size := int64(-1)
+	if info, err := client.Stat(full); err == nil {
+		size = info.Size()
+	}
+
+	f, err := client.Open(full)
+	if err != nil {
+		return nil, 0, err
+	}
+	return f, size, nil
+}
+
+func (s *sftpSource) Read(path string) ([]byte, error) {
+	rc, _, err := s.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer rc.Close()
+	return io.ReadAll(rc)
+}